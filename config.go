@@ -0,0 +1,372 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Backend is one configured EasyProxy instance.
+type Backend struct {
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	Password string `json:"password"`
+}
+
+// AddonConfig is the JSON blob base64url-encoded into the addon's configurable
+// install URL: /c/<base64url(config)>/manifest.json
+type AddonConfig struct {
+	Backends    []Backend `json:"backends"`
+	DefaultSort string    `json:"defaultSort,omitempty"`
+	HideActive  bool      `json:"hideActive,omitempty"`
+	TmdbKey     string    `json:"tmdbKey,omitempty"`
+}
+
+func encodeConfig(cfg AddonConfig) (string, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeConfig(encoded string) (AddonConfig, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return AddonConfig{}, err
+	}
+	var cfg AddonConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return AddonConfig{}, err
+	}
+	if len(cfg.Backends) == 0 {
+		return AddonConfig{}, fmt.Errorf("config has no backends")
+	}
+
+	// backendByName falls back to the legacy env-var backend for "" or
+	// "default", so a blank or colliding name here would silently resolve a
+	// user's meta/stream/proxy requests against someone else's EasyProxy
+	// instance instead of failing loudly.
+	seen := make(map[string]bool, len(cfg.Backends))
+	for _, b := range cfg.Backends {
+		if b.Name == "" {
+			return AddonConfig{}, fmt.Errorf("config has a backend with no name")
+		}
+		if seen[b.Name] {
+			return AddonConfig{}, fmt.Errorf("config has more than one backend named %q", b.Name)
+		}
+		seen[b.Name] = true
+	}
+
+	return cfg, nil
+}
+
+type contextKey int
+
+const configContextKey contextKey = 0
+
+// requestConfig bundles the decoded config with the raw encoded token it came
+// from, so handlers that mint new URLs (e.g. the stream proxy) can rebuild a
+// /c/<raw>/... link without re-encoding and risking a different byte layout.
+type requestConfig struct {
+	cfg AddonConfig
+	raw string
+}
+
+func withConfig(ctx context.Context, cfg AddonConfig, raw string) context.Context {
+	return context.WithValue(ctx, configContextKey, requestConfig{cfg: cfg, raw: raw})
+}
+
+// configFromRequest returns the AddonConfig decoded from a /c/<cfg>/ route, or
+// a single-backend config built from the legacy env-var globals when the
+// request came in through one of the un-configured routes.
+func configFromRequest(r *http.Request) AddonConfig {
+	if rc, ok := r.Context().Value(configContextKey).(requestConfig); ok {
+		return rc.cfg
+	}
+	return AddonConfig{
+		Backends: []Backend{{Name: "default", URL: easyProxyURL, Password: easyProxyPassword}},
+	}
+}
+
+// rawConfigFromRequest returns the raw base64url config token the request was
+// addressed to, if any.
+func rawConfigFromRequest(r *http.Request) (string, bool) {
+	if rc, ok := r.Context().Value(configContextKey).(requestConfig); ok {
+		return rc.raw, true
+	}
+	return "", false
+}
+
+// backendByName finds a configured backend by name, falling back to the
+// legacy globals if nothing matches (keeps old dvr:<id> links working).
+func backendByName(cfg AddonConfig, name string) (Backend, bool) {
+	for _, b := range cfg.Backends {
+		if b.Name == name {
+			return b, true
+		}
+	}
+	if name == "default" || name == "" {
+		return Backend{Name: "default", URL: easyProxyURL, Password: easyProxyPassword}, true
+	}
+	return Backend{}, false
+}
+
+// fetchFromBackend is fetchRecordings parameterized over a specific backend
+// instead of the package-level globals, routed through the shared httpClient
+// so every EasyProxy call gets the same retry/backoff behavior.
+func fetchFromBackend(ctx context.Context, b Backend) ([]Recording, error) {
+	params := url.Values{}
+	if b.Password != "" {
+		params.Set("api_password", b.Password)
+	}
+
+	reqURL := fmt.Sprintf("%s/api/recordings?%s", strings.TrimRight(b.URL, "/"), params.Encode())
+
+	headers := map[string]string{"Accept": "application/json"}
+	if b.Password != "" {
+		headers["x-api-password"] = b.Password
+	}
+
+	result, err := doJSON[RecordingsResponse](ctx, "GET", reqURL, headers)
+	if err != nil {
+		return nil, fmt.Errorf("backend %q: %w", b.Name, err)
+	}
+
+	// Tag every recording ID with its backend so a flat, merged list can still
+	// be routed back to the right EasyProxy instance later.
+	for i := range result.Recordings {
+		result.Recordings[i].ID = b.Name + ":" + result.Recordings[i].ID
+	}
+
+	return result.Recordings, nil
+}
+
+// fetchAllBackends fans out to every configured backend in parallel and
+// merges the results, tolerating individual backend failures (serving stale
+// data where available) so one private DVR being offline doesn't empty the
+// whole catalog.
+func fetchAllBackends(ctx context.Context, backends []Backend) ([]Recording, error) {
+	var mu sync.Mutex
+	var merged []Recording
+
+	g := new(errgroup.Group)
+	for _, b := range backends {
+		b := b
+		g.Go(func() error {
+			recs, err := fetchFromBackendCached(ctx, b)
+			if err != nil {
+				log.Printf("[DVR] Backend %q unavailable: %v", b.Name, err)
+				return nil
+			}
+			mu.Lock()
+			merged = append(merged, recs...)
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// splitBackendID splits a tagged recording ID ("<backend>:<id>") produced by
+// fetchFromBackend back into its backend name and the backend-local ID.
+func splitBackendID(taggedID string) (backendName, localID string) {
+	idx := strings.Index(taggedID, ":")
+	if idx < 0 {
+		return "default", taggedID
+	}
+	return taggedID[:idx], taggedID[idx+1:]
+}
+
+// Handler: Configured routes. Decodes /c/<cfg>/<rest...> and re-dispatches to
+// the matching handler with the config stashed in the request context.
+func handleConfigured(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/c/")
+	parts := strings.SplitN(rest, "/", 2)
+	if len(parts) != 2 {
+		http.NotFound(w, r)
+		return
+	}
+
+	cfg, err := decodeConfig(parts[0])
+	if err != nil {
+		http.Error(w, "invalid addon config", http.StatusBadRequest)
+		return
+	}
+
+	r2 := r.Clone(withConfig(r.Context(), cfg, parts[0]))
+	r2.URL.Path = "/" + parts[1]
+
+	switch {
+	case r2.URL.Path == "/manifest.json":
+		corsMiddleware(handleManifest)(w, r2)
+	case strings.HasPrefix(r2.URL.Path, "/catalog/"):
+		corsMiddleware(handleCatalog)(w, r2)
+	case strings.HasPrefix(r2.URL.Path, "/meta/"):
+		corsMiddleware(handleMeta)(w, r2)
+	case strings.HasPrefix(r2.URL.Path, "/stream/"):
+		corsMiddleware(handleStream)(w, r2)
+	case strings.HasPrefix(r2.URL.Path, "/proxy/"):
+		handleProxy(w, r2)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// Handler: Homepage. Renders a plain HTML form (no JS framework) that builds
+// the config blob client-side and produces both install URL flavors.
+func handleHome(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	host := r.Host
+
+	html := fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>DVR Recordings - Stremio Addon</title>
+    <style>
+        * { box-sizing: border-box; margin: 0; padding: 0; }
+        body {
+            font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, Oxygen, Ubuntu, sans-serif;
+            background: linear-gradient(135deg, #1a1a2e 0%%, #16213e 100%%);
+            min-height: 100vh;
+            display: flex;
+            align-items: center;
+            justify-content: center;
+            color: #fff;
+            padding: 2rem 0;
+        }
+        .container { text-align: center; padding: 2rem; max-width: 520px; width: 100%%; }
+        h1 { font-size: 2rem; margin-bottom: 0.5rem; font-weight: 600; }
+        .subtitle { color: #8892b0; margin-bottom: 1.5rem; font-size: 1.1rem; }
+        form { text-align: left; }
+        .backend-row { display: flex; gap: 0.5rem; margin-bottom: 0.5rem; }
+        input {
+            flex: 1;
+            background: #0d1117;
+            border: 1px solid #2a2a4a;
+            color: #fff;
+            padding: 0.5rem;
+            border-radius: 6px;
+            font-size: 0.85rem;
+        }
+        button {
+            background: #7b2cbf;
+            color: #fff;
+            border: none;
+            padding: 0.6rem 1.2rem;
+            border-radius: 6px;
+            cursor: pointer;
+            font-size: 0.9rem;
+            margin-top: 0.5rem;
+        }
+        .install-btn {
+            display: inline-block;
+            background: #7b2cbf;
+            color: #fff;
+            padding: 1rem 2.5rem;
+            border-radius: 50px;
+            text-decoration: none;
+            font-size: 1.1rem;
+            font-weight: 500;
+            margin-top: 1rem;
+        }
+        .manifest-url {
+            background: #0d1117;
+            padding: 0.75rem 1rem;
+            border-radius: 8px;
+            font-family: monospace;
+            font-size: 0.85rem;
+            color: #58a6ff;
+            word-break: break-all;
+            margin-top: 1rem;
+            display: none;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <h1>DVR Recordings</h1>
+        <p class="subtitle">Install against one or more EasyProxy backends</p>
+
+        <form id="cfgForm" onsubmit="return buildLinks()">
+            <div id="backends">
+                <div class="backend-row">
+                    <input name="name" placeholder="name" value="default">
+                    <input name="url" placeholder="https://easyproxy.example.com">
+                    <input name="password" placeholder="api password" type="password">
+                </div>
+            </div>
+            <button type="button" onclick="addBackend()">+ Add another backend</button>
+            <br>
+            <button type="submit">Generate install links</button>
+        </form>
+
+        <a id="installBtn" class="install-btn" style="display:none">Install Addon</a>
+        <div id="manifestUrl" class="manifest-url"></div>
+    </div>
+    <script>
+        var backendCount = 1;
+
+        function addBackend() {
+            backendCount++;
+            var row = document.createElement('div');
+            row.className = 'backend-row';
+            row.innerHTML = '<input name="name" placeholder="name" value="backend' + backendCount + '">' +
+                '<input name="url" placeholder="https://easyproxy.example.com">' +
+                '<input name="password" placeholder="api password" type="password">';
+            document.getElementById('backends').appendChild(row);
+        }
+
+        function buildLinks() {
+            var rows = document.querySelectorAll('#backends .backend-row');
+            var backends = [];
+            rows.forEach(function(row) {
+                var inputs = row.querySelectorAll('input');
+                backends.push({name: inputs[0].value, url: inputs[1].value, password: inputs[2].value});
+            });
+
+            var config = {backends: backends};
+            var json = JSON.stringify(config);
+            var b64 = btoa(json).replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '');
+
+            var manifestURL = '%s://%s/c/' + b64 + '/manifest.json';
+            var stremioURL = 'stremio://%s/c/' + b64 + '/manifest.json';
+
+            var btn = document.getElementById('installBtn');
+            btn.href = stremioURL;
+            btn.style.display = 'inline-block';
+
+            var urlBox = document.getElementById('manifestUrl');
+            urlBox.textContent = manifestURL;
+            urlBox.style.display = 'block';
+
+            return false;
+        }
+    </script>
+</body>
+</html>`, scheme, host, host)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(html))
+}
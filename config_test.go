@@ -0,0 +1,61 @@
+package main
+
+import "testing"
+
+func TestEncodeDecodeConfigRoundTrip(t *testing.T) {
+	cfg := AddonConfig{
+		Backends: []Backend{
+			{Name: "home", URL: "https://home.example.com", Password: "secret"},
+			{Name: "cabin", URL: "https://cabin.example.com", Password: ""},
+		},
+		DefaultSort: "newest",
+	}
+
+	encoded, err := encodeConfig(cfg)
+	if err != nil {
+		t.Fatalf("encodeConfig returned error: %v", err)
+	}
+
+	got, err := decodeConfig(encoded)
+	if err != nil {
+		t.Fatalf("decodeConfig returned error: %v", err)
+	}
+	if len(got.Backends) != 2 || got.Backends[0] != cfg.Backends[0] || got.Backends[1] != cfg.Backends[1] {
+		t.Errorf("decodeConfig round-trip = %+v, want %+v", got.Backends, cfg.Backends)
+	}
+	if got.DefaultSort != cfg.DefaultSort {
+		t.Errorf("DefaultSort = %q, want %q", got.DefaultSort, cfg.DefaultSort)
+	}
+}
+
+func TestDecodeConfigRejectsInvalidBackends(t *testing.T) {
+	tests := []struct {
+		name     string
+		backends []Backend
+	}{
+		{"no backends", nil},
+		{"blank name", []Backend{{Name: "", URL: "https://a.example.com"}}},
+		{"duplicate names", []Backend{
+			{Name: "default", URL: "https://a.example.com"},
+			{Name: "default", URL: "https://b.example.com"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := encodeConfig(AddonConfig{Backends: tt.backends})
+			if err != nil {
+				t.Fatalf("encodeConfig returned error: %v", err)
+			}
+			if _, err := decodeConfig(encoded); err == nil {
+				t.Errorf("decodeConfig(%v) returned nil error, want rejection", tt.backends)
+			}
+		})
+	}
+}
+
+func TestDecodeConfigMalformed(t *testing.T) {
+	if _, err := decodeConfig("not-valid-base64!!!"); err == nil {
+		t.Error("decodeConfig(garbage) returned nil error, want decode failure")
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// httpClient is the single client shared by every call out to an EasyProxy
+// backend, built once from env vars so a slow instance (common during active
+// recordings with large index scans) doesn't hang every handler indefinitely.
+var httpClient = buildHTTPClient()
+
+var httpRetries = getEnvInt("DVR_HTTP_RETRIES", 3)
+
+func buildHTTPClient() *http.Client {
+	timeout := getEnvDuration("DVR_HTTP_TIMEOUT", 30*time.Second)
+	connectTimeout := getEnvDuration("DVR_HTTP_CONNECT_TIMEOUT", 10*time.Second)
+
+	transport := &http.Transport{
+		DialContext: (&net.Dialer{Timeout: connectTimeout}).DialContext,
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}
+}
+
+// getEnvDuration reads an env var as a whole number of seconds, treating "-1"
+// as "disabled" (returned as 0, same meaning http.Client.Timeout gives it).
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	raw := getEnv(key, "")
+	if raw == "" {
+		return fallback
+	}
+	if raw == "-1" {
+		return 0
+	}
+	seconds, err := strconv.Atoi(raw)
+	if err != nil {
+		return fallback
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// doJSON performs an HTTP request with exponential backoff and jitter,
+// decoding a JSON response body into T. Only network errors and 5xx
+// responses are retried; a 4xx (bad password, not found, ...) or a
+// malformed body won't be fixed by retrying, so those return immediately.
+func doJSON[T any](ctx context.Context, method, url string, headers map[string]string) (T, error) {
+	var zero T
+	var lastErr error
+
+	for attempt := 0; attempt <= httpRetries; attempt++ {
+		if attempt > 0 {
+			sleepWithJitter(attempt)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, url, nil)
+		if err != nil {
+			return zero, err
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			lastErr = fmt.Errorf("%s %s returned status %d", method, url, resp.StatusCode)
+			if resp.StatusCode >= 500 {
+				continue
+			}
+			return zero, lastErr
+		}
+
+		var result T
+		if err := json.Unmarshal(body, &result); err != nil {
+			return zero, err
+		}
+		return result, nil
+	}
+
+	return zero, lastErr
+}
+
+func sleepWithJitter(attempt int) {
+	base := 200 * time.Millisecond * time.Duration(1<<uint(attempt-1))
+	jitter := time.Duration(rand.Int63n(int64(base)/2 + 1))
+	time.Sleep(base + jitter)
+}
+
+// staleTTL is how long a backend's last-good recordings list stays servable
+// after that backend starts failing.
+var staleTTL = getEnvDuration("DVR_STALE_TTL", 5*time.Minute)
+
+type cachedRecordings struct {
+	recordings []Recording
+	fetchedAt  time.Time
+}
+
+var recordingsCacheMu sync.Mutex
+var recordingsCache = map[string]cachedRecordings{}
+
+// backendCacheKey identifies a backend by what it actually connects to
+// rather than its free-text display Name: since chunk0-5, Name is
+// user-supplied per install and the config form pre-fills it with
+// "default", so two different users' backends can share a Name while
+// pointing at entirely different (and private) EasyProxy instances.
+func backendCacheKey(b Backend) string {
+	sum := sha256.Sum256([]byte(b.URL + "\x00" + b.Password))
+	return hex.EncodeToString(sum[:])
+}
+
+// fetchFromBackendCached wraps fetchFromBackend with a last-good cache: when
+// the live call fails, a cached list younger than DVR_STALE_TTL is returned
+// instead of an empty catalog, with every recording flagged Stale.
+func fetchFromBackendCached(ctx context.Context, b Backend) ([]Recording, error) {
+	key := backendCacheKey(b)
+
+	recs, err := fetchFromBackend(ctx, b)
+	if err == nil {
+		recordingsCacheMu.Lock()
+		recordingsCache[key] = cachedRecordings{recordings: recs, fetchedAt: time.Now()}
+		recordingsCacheMu.Unlock()
+		return recs, nil
+	}
+
+	recordingsCacheMu.Lock()
+	cached, ok := recordingsCache[key]
+	recordingsCacheMu.Unlock()
+	if !ok || time.Since(cached.fetchedAt) > staleTTL {
+		return nil, err
+	}
+
+	stale := make([]Recording, len(cached.recordings))
+	copy(stale, cached.recordings)
+	for i := range stale {
+		stale[i].Stale = true
+	}
+	return stale, nil
+}
+
+// probeBackend reports whether a backend's recordings endpoint is reachable,
+// along with how long the probe took.
+func probeBackend(ctx context.Context, b Backend) (bool, time.Duration, error) {
+	start := time.Now()
+	_, err := fetchFromBackend(ctx, b)
+	return err == nil, time.Since(start), err
+}
+
+// Handler: /healthz. Probes the legacy default backend and reports whether
+// its last known-good recordings list is still within the stale window.
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	backend := Backend{Name: "default", URL: easyProxyURL, Password: easyProxyPassword}
+	healthy, latency, err := probeBackend(r.Context(), backend)
+
+	status := "ok"
+	if !healthy {
+		recordingsCacheMu.Lock()
+		cached, ok := recordingsCache[backendCacheKey(backend)]
+		recordingsCacheMu.Unlock()
+		if ok && time.Since(cached.fetchedAt) <= staleTTL {
+			status = "degraded"
+		} else {
+			status = "down"
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if status == "down" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	resp := map[string]interface{}{
+		"status":     status,
+		"latency_ms": latency.Milliseconds(),
+	}
+	if err != nil {
+		resp["error"] = err.Error()
+	}
+	json.NewEncoder(w).Encode(resp)
+}
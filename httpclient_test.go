@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+type testPayload struct {
+	OK bool `json:"ok"`
+}
+
+func TestDoJSONRetriesOnServerErrorsOnly(t *testing.T) {
+	origRetries := httpRetries
+	httpRetries = 2
+	defer func() { httpRetries = origRetries }()
+
+	tests := []struct {
+		name        string
+		statusCode  int
+		wantErr     bool
+		wantAttempts int32
+	}{
+		{"5xx is retried", http.StatusServiceUnavailable, true, int32(httpRetries) + 1},
+		{"4xx is not retried", http.StatusUnauthorized, true, 1},
+		{"200 succeeds on first try", http.StatusOK, false, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&attempts, 1)
+				w.WriteHeader(tt.statusCode)
+				if tt.statusCode == http.StatusOK {
+					w.Write([]byte(`{"ok":true}`))
+				}
+			}))
+			defer srv.Close()
+
+			_, err := doJSON[testPayload](context.Background(), "GET", srv.URL, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("doJSON error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := atomic.LoadInt32(&attempts); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestBackendCacheKeyDistinguishesSameNameBackends(t *testing.T) {
+	a := Backend{Name: "default", URL: "https://user-a.example.com", Password: "secret-a"}
+	b := Backend{Name: "default", URL: "https://user-b.example.com", Password: "secret-b"}
+
+	if backendCacheKey(a) == backendCacheKey(b) {
+		t.Fatal("backendCacheKey collided for two different backends sharing a Name")
+	}
+	if backendCacheKey(a) != backendCacheKey(a) {
+		t.Fatal("backendCacheKey not stable for the same backend")
+	}
+}
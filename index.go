@@ -0,0 +1,426 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+var indexDB *sql.DB
+
+func openIndexDB(path string) (*sql.DB, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS recording_index (
+			id               TEXT PRIMARY KEY,
+			name             TEXT,
+			file_path        TEXT,
+			file_hash        TEXT,
+			file_size_bytes  INTEGER,
+			last_seen        INTEGER,
+			is_duplicate     INTEGER NOT NULL DEFAULT 0
+		);
+		CREATE TABLE IF NOT EXISTS watch_progress (
+			recording_id     TEXT PRIMARY KEY,
+			position_seconds REAL NOT NULL,
+			duration_seconds REAL NOT NULL,
+			updated_at       INTEGER NOT NULL
+		);
+	`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func recordingHash(rec Recording) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%s|%d", rec.FilePath, rec.FileSizeBytes)
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// syncIndex mirrors the current fetchRecordings() output into the local
+// SQLite index, stamping each row with last_seen and a content hash so
+// duplicate detection survives restarts.
+func syncIndex(recordings []Recording) error {
+	if indexDB == nil {
+		return nil
+	}
+	now := time.Now().Unix()
+	for _, rec := range recordings {
+		_, err := indexDB.Exec(`
+			INSERT INTO recording_index (id, name, file_path, file_hash, file_size_bytes, last_seen)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT(id) DO UPDATE SET name=excluded.name, file_path=excluded.file_path,
+				file_hash=excluded.file_hash, file_size_bytes=excluded.file_size_bytes, last_seen=excluded.last_seen
+		`, rec.ID, rec.Name, rec.FilePath, recordingHash(rec), rec.FileSizeBytes, now)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WatchProgress is a user's resume position for a single recording.
+type WatchProgress struct {
+	RecordingID string  `json:"recording_id"`
+	Position    float64 `json:"position_seconds"`
+	Duration    float64 `json:"duration_seconds"`
+	UpdatedAt   int64   `json:"updated_at"`
+}
+
+func saveProgress(p WatchProgress) error {
+	_, err := indexDB.Exec(`
+		INSERT INTO watch_progress (recording_id, position_seconds, duration_seconds, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(recording_id) DO UPDATE SET position_seconds=excluded.position_seconds,
+			duration_seconds=excluded.duration_seconds, updated_at=excluded.updated_at
+	`, p.RecordingID, p.Position, p.Duration, p.UpdatedAt)
+	return err
+}
+
+func getProgress(recordingID string) (WatchProgress, bool) {
+	var p WatchProgress
+	p.RecordingID = recordingID
+	row := indexDB.QueryRow(`SELECT position_seconds, duration_seconds, updated_at FROM watch_progress WHERE recording_id = ?`, recordingID)
+	if err := row.Scan(&p.Position, &p.Duration, &p.UpdatedAt); err != nil {
+		return WatchProgress{}, false
+	}
+	return p, true
+}
+
+// continueWatchingIDs returns recording IDs with 30s < position < duration-60s,
+// ordered by most recently watched.
+func continueWatchingIDs() ([]string, error) {
+	rows, err := indexDB.Query(`
+		SELECT recording_id FROM watch_progress
+		WHERE position_seconds > 30 AND position_seconds < duration_seconds - 60
+		ORDER BY updated_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Handler: Continue Watching catalog, built from progress rows.
+func handleContinueWatchingCatalog(w http.ResponseWriter, r *http.Request) {
+	ids, err := continueWatchingIDs()
+	if err != nil {
+		log.Printf("[DVR] Error reading continue-watching index: %v", err)
+		jsonResponse(w, map[string][]StremioMeta{"metas": {}})
+		return
+	}
+
+	recordings, err := fetchRecordings(r.Context())
+	if err != nil {
+		jsonResponse(w, map[string][]StremioMeta{"metas": {}})
+		return
+	}
+	byID := make(map[string]Recording, len(recordings))
+	for _, rec := range recordings {
+		byID[rec.ID] = rec
+	}
+
+	tmdbKey := resolveTmdbKey(configFromRequest(r))
+	var metas []StremioMeta
+	for _, id := range ids {
+		rec, ok := byID[id]
+		if !ok {
+			continue
+		}
+		metas = append(metas, recordingToMeta(rec, tmdbKey))
+	}
+
+	jsonResponse(w, map[string][]StremioMeta{"metas": metas})
+}
+
+// Handler: POST /progress/<id>. Stores the resume position a Stremio player
+// (or userscript) reports for a recording.
+func handleProgress(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	recordingID := strings.TrimPrefix(r.URL.Path, "/progress/")
+	if recordingID == "" {
+		http.Error(w, "missing recording id", http.StatusBadRequest)
+		return
+	}
+
+	var body struct {
+		Position float64 `json:"position_seconds"`
+		Duration float64 `json:"duration_seconds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	err := saveProgress(WatchProgress{
+		RecordingID: recordingID,
+		Position:    body.Position,
+		Duration:    body.Duration,
+		UpdatedAt:   time.Now().Unix(),
+	})
+	if err != nil {
+		log.Printf("[DVR] Error saving progress for %s: %v", recordingID, err)
+		http.Error(w, "failed to save progress", http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]bool{"ok": true})
+}
+
+// streamFragmentFor returns a "#t=<seconds>" fragment for a recording's stored
+// resume position, or "" if there is none worth seeking to.
+func streamFragmentFor(recordingID string) string {
+	progress, ok := getProgress(recordingID)
+	if !ok || progress.Position <= 30 {
+		return ""
+	}
+	return fmt.Sprintf("#t=%d", int(progress.Position))
+}
+
+// duplicateGroup is a set of recording IDs the housekeeping pass considers
+// copies of the same capture, largest first.
+type duplicateGroup struct {
+	Key string   `json:"key"`
+	IDs []string `json:"ids"`
+}
+
+// findDuplicates groups recordings sharing a file hash, or sharing a
+// normalized name within a short time window of each other, and marks every
+// row but the largest as a duplicate in the index.
+func findDuplicates(recordings []Recording, window time.Duration) ([]duplicateGroup, error) {
+	byHash := map[string][]Recording{}
+	for _, rec := range recordings {
+		byHash[recordingHash(rec)] = append(byHash[recordingHash(rec)], rec)
+	}
+
+	byName := map[string][]Recording{}
+	for _, rec := range recordings {
+		byName[normalizeName(rec.Name)] = append(byName[normalizeName(rec.Name)], rec)
+	}
+
+	var groups []duplicateGroup
+	seen := map[string]bool{}
+
+	consider := func(group []Recording) {
+		if len(group) < 2 {
+			return
+		}
+		sortedIDs := make([]string, 0, len(group))
+		for _, rec := range group {
+			sortedIDs = append(sortedIDs, rec.ID)
+		}
+		key := strings.Join(sortedIDs, ",")
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+
+		largest := group[0]
+		for _, rec := range group[1:] {
+			if rec.FileSizeBytes > largest.FileSizeBytes {
+				largest = rec
+			}
+		}
+
+		var ids []string
+		ids = append(ids, largest.ID)
+		for _, rec := range group {
+			if rec.ID != largest.ID {
+				ids = append(ids, rec.ID)
+			}
+		}
+		groups = append(groups, duplicateGroup{Key: key, IDs: ids})
+
+		if indexDB != nil {
+			for _, rec := range group {
+				if rec.ID == largest.ID {
+					continue
+				}
+				indexDB.Exec(`UPDATE recording_index SET is_duplicate = 1 WHERE id = ?`, rec.ID)
+			}
+		}
+	}
+
+	for _, group := range byHash {
+		consider(group)
+	}
+	for _, group := range byName {
+		for _, cluster := range clusterByProximity(group, window) {
+			consider(cluster)
+		}
+	}
+
+	return groups, nil
+}
+
+// isDuplicateIDs returns the set of recording IDs the housekeeping pass has
+// flagged as a duplicate, so the recordings catalog can hide every copy but
+// the largest.
+func isDuplicateIDs() (map[string]bool, error) {
+	if indexDB == nil {
+		return nil, nil
+	}
+	rows, err := indexDB.Query(`SELECT id FROM recording_index WHERE is_duplicate = 1`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := map[string]bool{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
+
+func normalizeName(name string) string {
+	name = strings.ToLower(name)
+	name = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '.' || r == '_' || r == '-' {
+			return -1
+		}
+		return r
+	}, name)
+	return name
+}
+
+// clusterByProximity groups a same-name set of recordings into clusters of
+// consecutive airings no more than window apart, chaining adjacent pairs
+// rather than gating the whole set on its overall first-to-last span. That
+// way a recurring show's nightly airings, each within window of its
+// immediate neighbor, keep clustering together indefinitely instead of the
+// group losing dedup the moment its total span exceeds window. Recordings
+// whose StartedAt doesn't parse never cluster with anything.
+func clusterByProximity(recordings []Recording, window time.Duration) [][]Recording {
+	type timedRecording struct {
+		rec Recording
+		t   time.Time
+	}
+	var timed []timedRecording
+	var clusters [][]Recording
+	for _, rec := range recordings {
+		t, err := time.Parse(time.RFC3339, rec.StartedAt)
+		if err != nil {
+			clusters = append(clusters, []Recording{rec})
+			continue
+		}
+		timed = append(timed, timedRecording{rec: rec, t: t})
+	}
+
+	sort.Slice(timed, func(i, j int) bool { return timed[i].t.Before(timed[j].t) })
+
+	var current []Recording
+	var last time.Time
+	for _, tr := range timed {
+		if len(current) > 0 && tr.t.Sub(last) > window {
+			clusters = append(clusters, current)
+			current = nil
+		}
+		current = append(current, tr.rec)
+		last = tr.t
+	}
+	if len(current) > 0 {
+		clusters = append(clusters, current)
+	}
+
+	return clusters
+}
+
+// runHousekeeping periodically re-scans the recordings list for duplicates.
+func runHousekeeping(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		recordings, err := fetchRecordings(context.Background())
+		if err != nil {
+			log.Printf("[DVR] Housekeeping: error fetching recordings: %v", err)
+			continue
+		}
+		if err := syncIndex(recordings); err != nil {
+			log.Printf("[DVR] Housekeeping: error syncing index: %v", err)
+		}
+		groups, err := findDuplicates(recordings, 10*time.Minute)
+		if err != nil {
+			log.Printf("[DVR] Housekeeping: error finding duplicates: %v", err)
+			continue
+		}
+		if len(groups) > 0 {
+			log.Printf("[DVR] Housekeeping: found %d duplicate group(s)", len(groups))
+		}
+	}
+}
+
+// Handler: /admin/housekeeping. Exposed as a Stremio action stream so the user
+// can purge the smaller copy of each duplicate group via the EasyProxy delete API.
+func handleHousekeepingCatalog(w http.ResponseWriter, r *http.Request) {
+	recordings, err := fetchRecordings(r.Context())
+	if err != nil {
+		jsonResponse(w, map[string][]StremioStream{"streams": {}})
+		return
+	}
+
+	groups, err := findDuplicates(recordings, 10*time.Minute)
+	if err != nil {
+		jsonResponse(w, map[string][]StremioStream{"streams": {}})
+		return
+	}
+
+	params := url.Values{}
+	if easyProxyPassword != "" {
+		params.Set("api_password", easyProxyPassword)
+	}
+
+	var streams []StremioStream
+	for _, group := range groups {
+		for _, id := range group.IDs[1:] {
+			_, localID := splitBackendID(id)
+			deleteURL := fmt.Sprintf("%s/api/recordings/%s/delete?%s", easyProxyURL, localID, params.Encode())
+			streams = append(streams, StremioStream{
+				URL:   deleteURL,
+				Title: fmt.Sprintf("Purge duplicate %s", id),
+			})
+		}
+	}
+
+	jsonResponse(w, map[string][]StremioStream{"streams": streams})
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestOpenIndexDBCreatesParentDir reproduces a fresh checkout where ./data
+// doesn't exist yet: opening the index at a path whose directory is missing
+// must create it rather than fail.
+func TestOpenIndexDBCreatesParentDir(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "index.db")
+
+	db, err := openIndexDB(path)
+	if err != nil {
+		t.Fatalf("openIndexDB returned error: %v", err)
+	}
+	defer db.Close()
+}
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Show Name - Episode.mkv", "shownameepisodemkv"},
+		{"My_Show.S01E01", "myshows01e01"},
+		{"already-normalized", "alreadynormalized"},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeName(tt.in); got != tt.want {
+			t.Errorf("normalizeName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFindDuplicatesByHash(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	recordings := []Recording{
+		{ID: "a", Name: "Show S01E01", FilePath: "/rec/a.ts", FileSizeBytes: 1000, StartedAt: base.Format(time.RFC3339)},
+		{ID: "b", Name: "Show S01E01 copy", FilePath: "/rec/a.ts", FileSizeBytes: 1000, StartedAt: base.Format(time.RFC3339)},
+		{ID: "c", Name: "Unrelated", FilePath: "/rec/c.ts", FileSizeBytes: 500, StartedAt: base.Format(time.RFC3339)},
+	}
+
+	groups, err := findDuplicates(recordings, 10*time.Minute)
+	if err != nil {
+		t.Fatalf("findDuplicates returned error: %v", err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("len(groups) = %d, want 1", len(groups))
+	}
+	if len(groups[0].IDs) != 2 || groups[0].IDs[0] != "a" {
+		t.Errorf("groups[0].IDs = %v, want largest (\"a\") first, then duplicates", groups[0].IDs)
+	}
+}
+
+func TestFindDuplicatesByNameWithinWindow(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	recordings := []Recording{
+		{ID: "a", Name: "Show - Episode", FilePath: "/rec/a.ts", FileSizeBytes: 2000, StartedAt: base.Format(time.RFC3339)},
+		{ID: "b", Name: "show_episode", FilePath: "/rec/b.ts", FileSizeBytes: 900, StartedAt: base.Add(2 * time.Minute).Format(time.RFC3339)},
+		{ID: "c", Name: "Other Show", FilePath: "/rec/c.ts", FileSizeBytes: 900, StartedAt: base.Add(time.Hour).Format(time.RFC3339)},
+	}
+
+	groups, err := findDuplicates(recordings, 10*time.Minute)
+	if err != nil {
+		t.Fatalf("findDuplicates returned error: %v", err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("len(groups) = %d, want 1 (b is within the window of a, c has a different name)", len(groups))
+	}
+	if groups[0].IDs[0] != "a" {
+		t.Errorf("groups[0].IDs[0] = %q, want largest (\"a\")", groups[0].IDs[0])
+	}
+}
+
+// TestFindDuplicatesByNameClustersRecurringShow reproduces a recurring show
+// whose consecutive nightly airings are each within window of their
+// immediate neighbor, even though the first and last airing (a and c) are
+// two days apart. Each night should still dedup against the one before it.
+func TestFindDuplicatesByNameClustersRecurringShow(t *testing.T) {
+	base := time.Date(2026, 1, 1, 20, 0, 0, 0, time.UTC)
+	recordings := []Recording{
+		{ID: "a", Name: "Nightly News", FilePath: "/rec/a.ts", FileSizeBytes: 1000, StartedAt: base.Format(time.RFC3339)},
+		{ID: "b", Name: "Nightly News", FilePath: "/rec/b.ts", FileSizeBytes: 900, StartedAt: base.Add(2 * time.Minute).Format(time.RFC3339)},
+		{ID: "c", Name: "Nightly News", FilePath: "/rec/c.ts", FileSizeBytes: 1100, StartedAt: base.Add(24 * time.Hour).Format(time.RFC3339)},
+		{ID: "d", Name: "Nightly News", FilePath: "/rec/d.ts", FileSizeBytes: 950, StartedAt: base.Add(24*time.Hour + 2*time.Minute).Format(time.RFC3339)},
+	}
+
+	groups, err := findDuplicates(recordings, 10*time.Minute)
+	if err != nil {
+		t.Fatalf("findDuplicates returned error: %v", err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("len(groups) = %d, want 2 (one cluster per night); groups=%v", len(groups), groups)
+	}
+
+	byKey := map[string]duplicateGroup{}
+	for _, g := range groups {
+		byKey[g.Key] = g
+	}
+	if g, ok := byKey["a,b"]; !ok || g.IDs[0] != "a" {
+		t.Errorf("expected a night-1 cluster {a,b} with largest (\"a\") first, got groups=%v", groups)
+	}
+	if g, ok := byKey["c,d"]; !ok || g.IDs[0] != "c" {
+		t.Errorf("expected a night-2 cluster {c,d} with largest (\"c\") first, got groups=%v", groups)
+	}
+}
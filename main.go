@@ -1,9 +1,9 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"net/url"
@@ -11,6 +11,8 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"golang.org/x/sync/errgroup"
 )
 
 // Configuration
@@ -27,7 +29,11 @@ var manifest = map[string]interface{}{
 	"name":        "DVR Recordings",
 	"description": "Local addon for EasyProxy DVR recordings",
 	"resources":   []string{"catalog", "stream", "meta"},
-	"types":       []string{"tv"},
+	// "tv" is the catalog type every recording starts as; "movie"/"series"
+	// are declared too because enrichMeta (metadata.go) promotes a meta's
+	// Type to one of those on a TMDB hit, and Stremio calls /meta/<type>/...
+	// and /stream/<type>/... using whatever type the catalog handed it.
+	"types": []string{"tv", "movie", "series"},
 	"catalogs": []map[string]interface{}{
 		{
 			"type": "tv",
@@ -45,8 +51,18 @@ var manifest = map[string]interface{}{
 				},
 			},
 		},
+		{
+			"type": "tv",
+			"id":   "dvr-schedule",
+			"name": "DVR Schedule",
+		},
+		{
+			"type": "tv",
+			"id":   "dvr-continue",
+			"name": "Continue Watching",
+		},
 	},
-	"idPrefixes": []string{"dvr:"},
+	"idPrefixes": []string{"dvr:", "sched:"},
 }
 
 // Recording represents an EasyProxy recording
@@ -62,6 +78,7 @@ type Recording struct {
 	FileSizeBytes   int64   `json:"file_size_bytes,omitempty"`
 	IsActive        bool    `json:"is_active"`
 	ElapsedSeconds  float64 `json:"elapsed_seconds,omitempty"`
+	Stale           bool    `json:"-"`
 }
 
 // RecordingsResponse from EasyProxy API
@@ -71,19 +88,30 @@ type RecordingsResponse struct {
 
 // StremioMeta for catalog items
 type StremioMeta struct {
-	ID          string `json:"id"`
-	Type        string `json:"type"`
-	Name        string `json:"name"`
-	Poster      string `json:"poster,omitempty"`
-	Description string `json:"description,omitempty"`
-	ReleaseInfo string `json:"releaseInfo,omitempty"`
-	Runtime     string `json:"runtime,omitempty"`
+	ID          string         `json:"id"`
+	Type        string         `json:"type"`
+	Name        string         `json:"name"`
+	Poster      string         `json:"poster,omitempty"`
+	Background  string         `json:"background,omitempty"`
+	Description string         `json:"description,omitempty"`
+	ReleaseInfo string         `json:"releaseInfo,omitempty"`
+	Runtime     string         `json:"runtime,omitempty"`
+	Videos      []StremioVideo `json:"videos,omitempty"`
+}
+
+// StremioVideo is one episode entry in a grouped series meta's videos array.
+type StremioVideo struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Season  int    `json:"season"`
+	Episode int    `json:"episode"`
 }
 
 // StremioStream for stream items
 type StremioStream struct {
-	URL   string `json:"url"`
-	Title string `json:"title"`
+	URL           string                 `json:"url"`
+	Title         string                 `json:"title"`
+	BehaviorHints map[string]interface{} `json:"behaviorHints,omitempty"`
 }
 
 func init() {
@@ -103,7 +131,7 @@ func getEnv(key, fallback string) string {
 func corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Origin, X-Requested-With, Content-Type, Accept")
 
 		if r.Method == "OPTIONS" {
@@ -121,47 +149,12 @@ func jsonResponse(w http.ResponseWriter, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
-// Fetch recordings from EasyProxy
-func fetchRecordings() ([]Recording, error) {
-	params := url.Values{}
-	if easyProxyPassword != "" {
-		params.Set("api_password", easyProxyPassword)
-	}
-
-	reqURL := fmt.Sprintf("%s/api/recordings?%s", easyProxyURL, params.Encode())
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	req, err := http.NewRequest("GET", reqURL, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("Accept", "application/json")
-	if easyProxyPassword != "" {
-		req.Header.Set("x-api-password", easyProxyPassword)
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	var result RecordingsResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, err
-	}
-
-	return result.Recordings, nil
+// fetchRecordings fetches from the legacy env-var-configured backend. Kept
+// for the subsystems (scheduler, housekeeping) that aren't config-in-URL
+// aware and only ever talk to the one backend the addon itself was started
+// with.
+func fetchRecordings(ctx context.Context) ([]Recording, error) {
+	return fetchFromBackendCached(ctx, Backend{Name: "default", URL: easyProxyURL, Password: easyProxyPassword})
 }
 
 // Format duration as human readable
@@ -192,8 +185,22 @@ func formatFileSize(bytes int64) string {
 	return fmt.Sprintf("%.1f%s", size, units[unitIndex])
 }
 
+// sortRecordings orders recs in place per an AddonConfig's DefaultSort:
+// "oldest" or "name", falling back to newest-first (the original hardcoded
+// behavior) for "" or any value it doesn't recognize.
+func sortRecordings(recs []Recording, mode string) {
+	switch mode {
+	case "oldest":
+		sort.Slice(recs, func(i, j int) bool { return recs[i].StartedAt < recs[j].StartedAt })
+	case "name":
+		sort.Slice(recs, func(i, j int) bool { return strings.ToLower(recs[i].Name) < strings.ToLower(recs[j].Name) })
+	default:
+		sort.Slice(recs, func(i, j int) bool { return recs[i].StartedAt > recs[j].StartedAt })
+	}
+}
+
 // Convert recording to Stremio meta
-func recordingToMeta(rec Recording) StremioMeta {
+func recordingToMeta(rec Recording, tmdbKey string) StremioMeta {
 	size := formatFileSize(rec.FileSizeBytes)
 
 	var date string
@@ -243,7 +250,11 @@ func recordingToMeta(rec Recording) StremioMeta {
 		runtime = duration
 	}
 
-	return StremioMeta{
+	if rec.Stale {
+		description = "[Stale] " + description
+	}
+
+	meta := StremioMeta{
 		ID:          "dvr:" + rec.ID,
 		Type:        "tv",
 		Name:        name,
@@ -251,6 +262,71 @@ func recordingToMeta(rec Recording) StremioMeta {
 		ReleaseInfo: date,
 		Runtime:     runtime,
 	}
+
+	// Active recordings keep the plain "in progress" meta; only finished
+	// recordings are worth a TMDB round-trip.
+	if !(rec.IsActive && rec.Status == "recording") {
+		meta = enrichMeta(meta, rec.Name, tmdbKey)
+	}
+
+	return meta
+}
+
+// episodeIDSuffix marks a grouped-series video ID (schedule.go-style "dvr:"
+// prefix handling happens in the caller) as pointing at one specific episode
+// recording rather than a plain top-level recording meta.
+const episodeIDSuffix = ":ep"
+
+// stripEpisodeSuffix strips the trailing ":ep" groupSeriesMetas appends to a
+// video ID, recovering the plain tagged recording ID handleMeta/handleStream
+// look up against fetchFromBackend's results.
+func stripEpisodeSuffix(recordingID string) string {
+	return strings.TrimSuffix(recordingID, episodeIDSuffix)
+}
+
+// groupSeriesMetas collapses recordings that parsed as the same series into a
+// single meta with a videos array, so a show's episodes appear as one poster
+// in the catalog instead of one row per file.
+func groupSeriesMetas(recordings []Recording, metas []StremioMeta) []StremioMeta {
+	type seriesGroup struct {
+		meta   StremioMeta
+		videos []StremioVideo
+	}
+
+	groups := map[string]*seriesGroup{}
+	var order []string
+	var result []StremioMeta
+
+	for i, rec := range recordings {
+		meta := metas[i]
+		parsed := parseFilename(rec.Name)
+		if meta.Type != "series" || parsed.Kind != "series" {
+			result = append(result, meta)
+			continue
+		}
+
+		key := strings.ToLower(meta.Name)
+		group, ok := groups[key]
+		if !ok {
+			group = &seriesGroup{meta: meta}
+			groups[key] = group
+			order = append(order, key)
+		}
+		group.videos = append(group.videos, StremioVideo{
+			ID:      "dvr:" + rec.ID + episodeIDSuffix,
+			Title:   parsed.EpTitle,
+			Season:  parsed.Season,
+			Episode: parsed.Episode,
+		})
+	}
+
+	for _, key := range order {
+		group := groups[key]
+		group.meta.Videos = group.videos
+		result = append(result, group.meta)
+	}
+
+	return result
 }
 
 // Handler: Manifest
@@ -263,7 +339,22 @@ func handleCatalog(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/catalog/")
 	parts := strings.Split(strings.TrimSuffix(path, ".json"), "/")
 
-	if len(parts) < 2 || parts[0] != "tv" || !strings.HasPrefix(parts[1], "dvr-recordings") {
+	if len(parts) < 2 || parts[0] != "tv" {
+		jsonResponse(w, map[string][]StremioMeta{"metas": {}})
+		return
+	}
+
+	if strings.HasPrefix(parts[1], "dvr-schedule") {
+		handleScheduleCatalog(w, r)
+		return
+	}
+
+	if strings.HasPrefix(parts[1], "dvr-continue") {
+		handleContinueWatchingCatalog(w, r)
+		return
+	}
+
+	if !strings.HasPrefix(parts[1], "dvr-recordings") {
 		jsonResponse(w, map[string][]StremioMeta{"metas": {}})
 		return
 	}
@@ -282,18 +373,31 @@ func handleCatalog(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	log.Printf("[DVR] Fetching recordings catalog (search: %q)...", searchQuery)
-	recordings, err := fetchRecordings()
+	cfg := configFromRequest(r)
+
+	log.Printf("[DVR] Fetching recordings catalog from %d backend(s) (search: %q)...", len(cfg.Backends), searchQuery)
+	recordings, err := fetchAllBackends(r.Context(), cfg.Backends)
 	if err != nil {
 		log.Printf("[DVR] Error fetching recordings: %v", err)
 		jsonResponse(w, map[string][]StremioMeta{"metas": {}})
 		return
 	}
 
+	duplicateIDs, err := isDuplicateIDs()
+	if err != nil {
+		log.Printf("[DVR] Error reading duplicate flags: %v", err)
+	}
+
 	// Separate active and completed recordings
 	var active []Recording
 	var completed []Recording
 	for _, rec := range recordings {
+		// Hide every copy housekeeping marked as a duplicate except the
+		// largest, which findDuplicates leaves unflagged.
+		if duplicateIDs[rec.ID] {
+			continue
+		}
+
 		// Apply search filter if present
 		if searchQuery != "" {
 			if !strings.Contains(strings.ToLower(rec.Name), searchQuery) {
@@ -302,6 +406,9 @@ func handleCatalog(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if rec.IsActive && rec.Status == "recording" {
+			if cfg.HideActive {
+				continue
+			}
 			active = append(active, rec)
 		} else {
 			hasValidFile := rec.FileSizeBytes > 0
@@ -317,36 +424,72 @@ func handleCatalog(w http.ResponseWriter, r *http.Request) {
 		return active[i].StartedAt > active[j].StartedAt
 	})
 
-	// Sort completed by date (newest first)
-	sort.Slice(completed, func(i, j int) bool {
-		return completed[i].StartedAt > completed[j].StartedAt
-	})
+	// Sort completed per the config's preferred order (newest first by default)
+	sortRecordings(completed, cfg.DefaultSort)
 
 	// Combine: active first, then completed
 	valid := append(active, completed...)
 
+	// recordingToMeta can hit TMDB on a cache miss, so build metas concurrently
+	// (bounded, since a large catalog shouldn't open dozens of outbound
+	// requests at once) instead of stalling the response one lookup at a time.
+	tmdbKey := resolveTmdbKey(cfg)
 	metas := make([]StremioMeta, len(valid))
+	g := new(errgroup.Group)
+	g.SetLimit(8)
 	for i, rec := range valid {
-		metas[i] = recordingToMeta(rec)
+		i, rec := i, rec
+		g.Go(func() error {
+			metas[i] = recordingToMeta(rec, tmdbKey)
+			return nil
+		})
 	}
+	g.Wait()
+	metas = groupSeriesMetas(valid, metas)
 
 	log.Printf("[DVR] Returning %d recordings", len(metas))
 	jsonResponse(w, map[string][]StremioMeta{"metas": metas})
 }
 
+// isMetaType reports whether t is one of the types this addon's metas can
+// carry: the catalog always starts items as "tv", but enrichMeta promotes a
+// meta to "movie"/"series" on a TMDB hit, and Stremio then calls back with
+// whichever type the meta declared.
+func isMetaType(t string) bool {
+	return t == "tv" || t == "movie" || t == "series"
+}
+
 // Handler: Meta
 func handleMeta(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/meta/")
 	parts := strings.Split(strings.TrimSuffix(path, ".json"), "/")
 
-	if len(parts) != 2 || parts[0] != "tv" || !strings.HasPrefix(parts[1], "dvr:") {
+	if len(parts) != 2 || !isMetaType(parts[0]) {
 		jsonResponse(w, map[string]interface{}{"meta": nil})
 		return
 	}
 
-	recordingID := strings.TrimPrefix(parts[1], "dvr:")
+	if strings.HasPrefix(parts[1], "sched:") {
+		handleScheduleMeta(w, strings.TrimPrefix(parts[1], "sched:"))
+		return
+	}
+
+	if !strings.HasPrefix(parts[1], "dvr:") {
+		jsonResponse(w, map[string]interface{}{"meta": nil})
+		return
+	}
+
+	recordingID := stripEpisodeSuffix(strings.TrimPrefix(parts[1], "dvr:"))
+
+	cfg := configFromRequest(r)
+	backendName, _ := splitBackendID(recordingID)
+	backend, ok := backendByName(cfg, backendName)
+	if !ok {
+		jsonResponse(w, map[string]interface{}{"meta": nil})
+		return
+	}
 
-	recordings, err := fetchRecordings()
+	recordings, err := fetchFromBackend(r.Context(), backend)
 	if err != nil {
 		jsonResponse(w, map[string]interface{}{"meta": nil})
 		return
@@ -354,7 +497,7 @@ func handleMeta(w http.ResponseWriter, r *http.Request) {
 
 	for _, rec := range recordings {
 		if rec.ID == recordingID {
-			jsonResponse(w, map[string]StremioMeta{"meta": recordingToMeta(rec)})
+			jsonResponse(w, map[string]StremioMeta{"meta": recordingToMeta(rec, resolveTmdbKey(cfg))})
 			return
 		}
 	}
@@ -367,20 +510,38 @@ func handleStream(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/stream/")
 	parts := strings.Split(strings.TrimSuffix(path, ".json"), "/")
 
-	if len(parts) != 2 || parts[0] != "tv" || !strings.HasPrefix(parts[1], "dvr:") {
+	if len(parts) != 2 || !isMetaType(parts[0]) {
 		jsonResponse(w, map[string][]StremioStream{"streams": {}})
 		return
 	}
 
-	recordingID := strings.TrimPrefix(parts[1], "dvr:")
+	if strings.HasPrefix(parts[1], "sched:") {
+		handleScheduleStream(w, r, strings.TrimPrefix(parts[1], "sched:"))
+		return
+	}
+
+	if !strings.HasPrefix(parts[1], "dvr:") {
+		jsonResponse(w, map[string][]StremioStream{"streams": {}})
+		return
+	}
+
+	recordingID := stripEpisodeSuffix(strings.TrimPrefix(parts[1], "dvr:"))
+
+	cfg := configFromRequest(r)
+	backendName, _ := splitBackendID(recordingID)
+	backend, ok := backendByName(cfg, backendName)
+	if !ok {
+		jsonResponse(w, map[string][]StremioStream{"streams": {}})
+		return
+	}
 
 	params := url.Values{}
-	if easyProxyPassword != "" {
-		params.Set("api_password", easyProxyPassword)
+	if backend.Password != "" {
+		params.Set("api_password", backend.Password)
 	}
 
 	// Check if recording is active
-	recordings, err := fetchRecordings()
+	recordings, err := fetchFromBackend(r.Context(), backend)
 	var isActive bool
 	if err == nil {
 		for _, rec := range recordings {
@@ -397,162 +558,65 @@ func handleStream(w http.ResponseWriter, r *http.Request) {
 
 	if isActive {
 		// Active recording: offer Stop & Watch
-		stopURL := fmt.Sprintf("%s/record/stop/%s?%s", easyProxyURL, recordingID, params.Encode())
+		stopURL := fmt.Sprintf("%s/record/stop/%s?%s", backend.URL, recordingID, params.Encode())
 		streams = append(streams, StremioStream{URL: stopURL, Title: "‚èπÔ∏è Stop & Watch"})
 	} else {
-		// Completed recording: offer Play and Delete
-		streamURL := fmt.Sprintf("%s/api/recordings/%s/stream?%s", easyProxyURL, recordingID, params.Encode())
-		deleteURL := fmt.Sprintf("%s/api/recordings/%s/delete?%s", easyProxyURL, recordingID, params.Encode())
-		streams = append(streams, StremioStream{URL: streamURL, Title: "‚ñ∂Ô∏è Play Recording"})
+		// Completed recording: offer Play and Delete. Play is routed through our
+		// own /proxy/ handler so Stremio never sees the EasyProxy api password,
+		// and carries a resume fragment plus bingeGroup so players seek to the
+		// stored progress and keep using the same stream across episodes.
+		streamURL := proxyStreamURL(r, backend, recordingID) + streamFragmentFor(recordingID)
+		deleteURL := fmt.Sprintf("%s/api/recordings/%s/delete?%s", backend.URL, recordingID, params.Encode())
+		streams = append(streams, StremioStream{
+			URL:   streamURL,
+			Title: "‚ñ∂Ô∏è Play Recording",
+			BehaviorHints: map[string]interface{}{
+				"bingeGroup": "dvr-recordings",
+			},
+		})
 		streams = append(streams, StremioStream{URL: deleteURL, Title: "üóëÔ∏è Delete Recording"})
 	}
 
 	jsonResponse(w, map[string][]StremioStream{"streams": streams})
 }
 
-// Handler: Homepage
-func handleHome(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path != "/" {
-		http.NotFound(w, r)
-		return
+func main() {
+	store, err := openScheduleStore(getEnv("DVR_SCHEDULE_DB", "./data/schedule.db"))
+	if err != nil {
+		log.Fatalf("[DVR] Failed to open schedule store: %v", err)
 	}
+	scheduleStore = store
 
-	// Get the host from the request to build the manifest URL
-	scheme := "http"
-	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
-		scheme = "https"
-	}
-	host := r.Host
-	manifestURL := fmt.Sprintf("%s://%s/manifest.json", scheme, host)
-	stremioURL := fmt.Sprintf("stremio://%s/manifest.json", host)
-
-	html := fmt.Sprintf(`<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>DVR Recordings - Stremio Addon</title>
-    <style>
-        * { box-sizing: border-box; margin: 0; padding: 0; }
-        body {
-            font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, Oxygen, Ubuntu, sans-serif;
-            background: linear-gradient(135deg, #1a1a2e 0%%, #16213e 100%%);
-            min-height: 100vh;
-            display: flex;
-            align-items: center;
-            justify-content: center;
-            color: #fff;
-        }
-        .container {
-            text-align: center;
-            padding: 2rem;
-            max-width: 500px;
-        }
-        .icon {
-            font-size: 4rem;
-            margin-bottom: 1rem;
-        }
-        h1 {
-            font-size: 2rem;
-            margin-bottom: 0.5rem;
-            font-weight: 600;
-        }
-        .subtitle {
-            color: #8892b0;
-            margin-bottom: 2rem;
-            font-size: 1.1rem;
-        }
-        .install-btn {
-            display: inline-block;
-            background: #7b2cbf;
-            color: #fff;
-            padding: 1rem 2.5rem;
-            border-radius: 50px;
-            text-decoration: none;
-            font-size: 1.1rem;
-            font-weight: 500;
-            transition: all 0.3s ease;
-            box-shadow: 0 4px 15px rgba(123, 44, 191, 0.4);
-        }
-        .install-btn:hover {
-            background: #9d4edd;
-            transform: translateY(-2px);
-            box-shadow: 0 6px 20px rgba(123, 44, 191, 0.5);
-        }
-        .manual {
-            margin-top: 2rem;
-            padding-top: 1.5rem;
-            border-top: 1px solid #2a2a4a;
-        }
-        .manual p {
-            color: #8892b0;
-            font-size: 0.9rem;
-            margin-bottom: 0.5rem;
-        }
-        .manifest-url {
-            background: #0d1117;
-            padding: 0.75rem 1rem;
-            border-radius: 8px;
-            font-family: monospace;
-            font-size: 0.85rem;
-            color: #58a6ff;
-            word-break: break-all;
-            cursor: pointer;
-            transition: background 0.2s;
-        }
-        .manifest-url:hover {
-            background: #161b22;
-        }
-        .features {
-            display: flex;
-            justify-content: center;
-            gap: 2rem;
-            margin: 2rem 0;
-            flex-wrap: wrap;
-        }
-        .feature {
-            color: #8892b0;
-            font-size: 0.9rem;
-        }
-        .feature span {
-            display: block;
-            font-size: 1.5rem;
-            margin-bottom: 0.25rem;
-        }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="icon">üìº</div>
-        <h1>DVR Recordings</h1>
-        <p class="subtitle">Access your EasyProxy DVR recordings in Stremio</p>
-
-        <div class="features">
-            <div class="feature"><span>üì∫</span>Browse</div>
-            <div class="feature"><span>üîç</span>Search</div>
-            <div class="feature"><span>‚ñ∂Ô∏è</span>Play</div>
-        </div>
-
-        <a href="%s" class="install-btn">Install Addon</a>
-
-        <div class="manual">
-            <p>Or copy the manifest URL:</p>
-            <div class="manifest-url" onclick="navigator.clipboard.writeText('%s')">%s</div>
-        </div>
-    </div>
-</body>
-</html>`, stremioURL, manifestURL, manifestURL)
-
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.Write([]byte(html))
-}
+	metaDB, err := openMetadataDB(getEnv("DVR_METADATA_DB", "./data/metadata.db"))
+	if err != nil {
+		log.Fatalf("[DVR] Failed to open metadata cache: %v", err)
+	}
+	metadataDB = metaDB
+
+	idxDB, err := openIndexDB(getEnv("DVR_INDEX_DB", "./data/index.db"))
+	if err != nil {
+		log.Fatalf("[DVR] Failed to open recording index: %v", err)
+	}
+	indexDB = idxDB
+
+	pollSeconds := getEnvInt("DVR_SCHEDULE_POLL_SECONDS", 30)
+	go runScheduler(scheduleStore, time.Duration(pollSeconds)*time.Second)
+
+	housekeepingMinutes := getEnvInt("DVR_HOUSEKEEPING_MINUTES", 15)
+	go runHousekeeping(time.Duration(housekeepingMinutes) * time.Minute)
 
-func main() {
 	http.HandleFunc("/", handleHome)
 	http.HandleFunc("/manifest.json", corsMiddleware(handleManifest))
 	http.HandleFunc("/catalog/", corsMiddleware(handleCatalog))
 	http.HandleFunc("/meta/", corsMiddleware(handleMeta))
 	http.HandleFunc("/stream/", corsMiddleware(handleStream))
+	http.HandleFunc("/proxy/", handleProxy)
+	http.HandleFunc("/progress/", corsMiddleware(handleProgress))
+	http.HandleFunc("/schedule/create", corsMiddleware(handleScheduleCreate))
+	http.HandleFunc("/schedule/cancel/", corsMiddleware(handleScheduleCancel))
+	http.HandleFunc("/admin/housekeeping", corsMiddleware(handleHousekeepingCatalog))
+	http.HandleFunc("/c/", handleConfigured)
+	http.HandleFunc("/healthz", handleHealthz)
 
 	log.Printf("[DVR] Stremio DVR addon running at http://localhost:%s", port)
 	log.Printf("[DVR] EasyProxy URL: %s", easyProxyURL)
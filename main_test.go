@@ -0,0 +1,77 @@
+package main
+
+import "testing"
+
+func TestIsMetaType(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"tv", true},
+		{"movie", true},
+		{"series", true},
+		{"channel", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isMetaType(tt.in); got != tt.want {
+			t.Errorf("isMetaType(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStripEpisodeSuffix(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"default:rec123:ep", "default:rec123"},
+		{"default:rec123", "default:rec123"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := stripEpisodeSuffix(tt.in); got != tt.want {
+			t.Errorf("stripEpisodeSuffix(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSortRecordings(t *testing.T) {
+	recs := func() []Recording {
+		return []Recording{
+			{ID: "a", Name: "Banana", StartedAt: "2026-01-02T00:00:00Z"},
+			{ID: "b", Name: "Apple", StartedAt: "2026-01-03T00:00:00Z"},
+			{ID: "c", Name: "Cherry", StartedAt: "2026-01-01T00:00:00Z"},
+		}
+	}
+
+	tests := []struct {
+		mode string
+		want []string
+	}{
+		{"", []string{"b", "a", "c"}},
+		{"newest", []string{"b", "a", "c"}},
+		{"oldest", []string{"c", "a", "b"}},
+		{"name", []string{"b", "a", "c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			got := recs()
+			sortRecordings(got, tt.mode)
+			var ids []string
+			for _, r := range got {
+				ids = append(ids, r.ID)
+			}
+			if len(ids) != len(tt.want) {
+				t.Fatalf("sortRecordings(%q) = %v, want %v", tt.mode, ids, tt.want)
+			}
+			for i := range ids {
+				if ids[i] != tt.want[i] {
+					t.Errorf("sortRecordings(%q) = %v, want %v", tt.mode, ids, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
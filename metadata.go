@@ -0,0 +1,274 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+var tmdbAPIKey = getEnv("TMDB_API_KEY", "")
+
+// resolveTmdbKey prefers a config's own TmdbKey, falling back to the global
+// TMDB_API_KEY env var so installs that don't set one keep working.
+func resolveTmdbKey(cfg AddonConfig) string {
+	if cfg.TmdbKey != "" {
+		return cfg.TmdbKey
+	}
+	return tmdbAPIKey
+}
+
+// ParsedFilename is the result of recognizing a recording's name as a series
+// episode, a movie, or neither.
+type ParsedFilename struct {
+	Kind     string // "series", "movie", or "" when unrecognized
+	Title    string
+	Year     string
+	Season   int
+	Episode  int
+	EpTitle  string
+}
+
+var (
+	seriesPattern  = regexp.MustCompile(`(?i)^(.+?)[.\s]+[Ss](\d{1,2})[Ee](\d{1,3})(?:[.\s]+(.+))?$`)
+	moviePattern   = regexp.MustCompile(`^(.+?)\s*\((\d{4})\)$`)
+	channelPattern = regexp.MustCompile(`^(.+?)\s*-\s*(.+?)\s*-\s*(\d{4}-\d{2}-\d{2})[ _](\d{4})$`)
+)
+
+// parseFilename recognizes the common PVR filename shapes this addon's users
+// tend to produce, e.g. "Show.Name.S02E05.Title", "Movie (2021)", and
+// "Channel - Program - YYYY-MM-DD HHMM".
+func parseFilename(name string) ParsedFilename {
+	clean := strings.TrimSuffix(name, filepathExt(name))
+
+	if m := seriesPattern.FindStringSubmatch(clean); m != nil {
+		season, _ := strconv.Atoi(m[2])
+		episode, _ := strconv.Atoi(m[3])
+		return ParsedFilename{
+			Kind:    "series",
+			Title:   dotsToSpaces(m[1]),
+			Season:  season,
+			Episode: episode,
+			EpTitle: dotsToSpaces(m[4]),
+		}
+	}
+
+	if m := channelPattern.FindStringSubmatch(clean); m != nil {
+		return ParsedFilename{
+			Kind:    "series",
+			Title:   strings.TrimSpace(m[2]),
+			EpTitle: m[3] + " " + m[4],
+		}
+	}
+
+	if m := moviePattern.FindStringSubmatch(clean); m != nil {
+		return ParsedFilename{
+			Kind:  "movie",
+			Title: strings.TrimSpace(m[1]),
+			Year:  m[2],
+		}
+	}
+
+	return ParsedFilename{}
+}
+
+func dotsToSpaces(s string) string {
+	return strings.TrimSpace(strings.ReplaceAll(s, ".", " "))
+}
+
+func filepathExt(name string) string {
+	if i := strings.LastIndex(name, "."); i > 0 {
+		ext := name[i:]
+		if len(ext) <= 5 && !strings.Contains(ext, " ") {
+			return ext
+		}
+	}
+	return ""
+}
+
+// TMDBInfo is the subset of a TMDB search result we care about for a catalog meta.
+type TMDBInfo struct {
+	Title       string
+	Poster      string
+	Background  string
+	Description string
+	ImdbRating  string
+}
+
+var metadataDB *sql.DB
+
+func openMetadataDB(path string) (*sql.DB, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// handleCatalog now resolves cache misses concurrently, so force a single
+	// connection: modernc.org/sqlite has no multi-writer story, and without
+	// this, concurrent cache INSERTs race for the write lock and silently
+	// fail instead of populating the 7-day cache.
+	db.SetMaxOpenConns(1)
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS tmdb_cache (
+			cache_key   TEXT PRIMARY KEY,
+			found       INTEGER NOT NULL,
+			title       TEXT,
+			poster      TEXT,
+			background  TEXT,
+			description TEXT,
+			imdb_rating TEXT,
+			fetched_at  INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+const tmdbCacheTTL = 7 * 24 * time.Hour
+
+// lookupTMDB resolves a title+year to poster/background/description/rating,
+// caching both hits and misses so a title that doesn't exist on TMDB isn't
+// looked up again on every catalog refresh.
+func lookupTMDB(title, year, apiKey string) (TMDBInfo, bool) {
+	cacheKey := strings.ToLower(title) + "|" + year
+
+	if metadataDB != nil {
+		var found int
+		var info TMDBInfo
+		var fetchedAt int64
+		row := metadataDB.QueryRow(`SELECT found, title, poster, background, description, imdb_rating, fetched_at FROM tmdb_cache WHERE cache_key = ?`, cacheKey)
+		if err := row.Scan(&found, &info.Title, &info.Poster, &info.Background, &info.Description, &info.ImdbRating, &fetchedAt); err == nil {
+			if time.Since(time.Unix(fetchedAt, 0)) < tmdbCacheTTL {
+				return info, found == 1
+			}
+		}
+	}
+
+	info, ok := fetchTMDB(title, year, apiKey)
+
+	if metadataDB != nil {
+		foundInt := 0
+		if ok {
+			foundInt = 1
+		}
+		metadataDB.Exec(`
+			INSERT INTO tmdb_cache (cache_key, found, title, poster, background, description, imdb_rating, fetched_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(cache_key) DO UPDATE SET found=excluded.found, title=excluded.title, poster=excluded.poster,
+				background=excluded.background, description=excluded.description, imdb_rating=excluded.imdb_rating, fetched_at=excluded.fetched_at
+		`, cacheKey, foundInt, info.Title, info.Poster, info.Background, info.Description, info.ImdbRating, time.Now().Unix())
+	}
+
+	return info, ok
+}
+
+type tmdbSearchResponse struct {
+	Results []struct {
+		Title         string  `json:"title"`
+		Name          string  `json:"name"`
+		Overview      string  `json:"overview"`
+		PosterPath    string  `json:"poster_path"`
+		BackdropPath  string  `json:"backdrop_path"`
+		VoteAverage   float64 `json:"vote_average"`
+	} `json:"results"`
+}
+
+func fetchTMDB(title, year, apiKey string) (TMDBInfo, bool) {
+	if apiKey == "" {
+		return TMDBInfo{}, false
+	}
+
+	params := url.Values{}
+	params.Set("api_key", apiKey)
+	params.Set("query", title)
+	if year != "" {
+		params.Set("year", year)
+	}
+
+	reqURL := fmt.Sprintf("https://api.themoviedb.org/3/search/multi?%s", params.Encode())
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(reqURL)
+	if err != nil {
+		log.Printf("[DVR] TMDB lookup failed for %q: %v", title, err)
+		return TMDBInfo{}, false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return TMDBInfo{}, false
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return TMDBInfo{}, false
+	}
+
+	var result tmdbSearchResponse
+	if err := json.Unmarshal(body, &result); err != nil || len(result.Results) == 0 {
+		return TMDBInfo{}, false
+	}
+
+	top := result.Results[0]
+	name := top.Title
+	if name == "" {
+		name = top.Name
+	}
+
+	info := TMDBInfo{
+		Title:       name,
+		Description: top.Overview,
+		ImdbRating:  fmt.Sprintf("%.1f", top.VoteAverage),
+	}
+	if top.PosterPath != "" {
+		info.Poster = "https://image.tmdb.org/t/p/w500" + top.PosterPath
+	}
+	if top.BackdropPath != "" {
+		info.Background = "https://image.tmdb.org/t/p/original" + top.BackdropPath
+	}
+
+	return info, true
+}
+
+// enrichMeta promotes a plain recording meta to a series/movie meta using the
+// parsed filename and a TMDB lookup, leaving the meta untouched when the name
+// doesn't match a recognized pattern or TMDB has no match.
+func enrichMeta(meta StremioMeta, rawName, tmdbKey string) StremioMeta {
+	parsed := parseFilename(rawName)
+	if parsed.Kind == "" || parsed.Title == "" {
+		return meta
+	}
+
+	info, ok := lookupTMDB(parsed.Title, parsed.Year, tmdbKey)
+	if !ok {
+		return meta
+	}
+
+	meta.Type = parsed.Kind
+	meta.Name = info.Title
+	meta.Poster = info.Poster
+	meta.Description = info.Description
+	if info.ImdbRating != "" {
+		meta.Description = fmt.Sprintf("%s\nIMDb: %s", meta.Description, info.ImdbRating)
+	}
+
+	return meta
+}
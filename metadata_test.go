@@ -0,0 +1,59 @@
+package main
+
+import "testing"
+
+func TestParseFilename(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want ParsedFilename
+	}{
+		{
+			name: "series with episode title",
+			in:   "Show.Name.S02E05.The.Title.mkv",
+			want: ParsedFilename{Kind: "series", Title: "Show Name", Season: 2, Episode: 5, EpTitle: "The Title"},
+		},
+		{
+			name: "series without episode title",
+			in:   "Show.Name.S01E01.mp4",
+			want: ParsedFilename{Kind: "series", Title: "Show Name", Season: 1, Episode: 1},
+		},
+		{
+			name: "movie with year",
+			in:   "Movie Title (2021).mkv",
+			want: ParsedFilename{Kind: "movie", Title: "Movie Title", Year: "2021"},
+		},
+		{
+			name: "channel/program/date",
+			in:   "BBC One - Panorama - 2024-03-05 2100.ts",
+			want: ParsedFilename{Kind: "series", Title: "Panorama", EpTitle: "2024-03-05 2100"},
+		},
+		{
+			name: "unrecognized",
+			in:   "random_capture_file.ts",
+			want: ParsedFilename{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseFilename(tt.in)
+			if got != tt.want {
+				t.Errorf("parseFilename(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveTmdbKey(t *testing.T) {
+	origGlobal := tmdbAPIKey
+	tmdbAPIKey = "global-key"
+	defer func() { tmdbAPIKey = origGlobal }()
+
+	if got := resolveTmdbKey(AddonConfig{TmdbKey: "per-user-key"}); got != "per-user-key" {
+		t.Errorf("resolveTmdbKey with a config key = %q, want %q", got, "per-user-key")
+	}
+	if got := resolveTmdbKey(AddonConfig{}); got != "global-key" {
+		t.Errorf("resolveTmdbKey with no config key = %q, want fallback %q", got, "global-key")
+	}
+}
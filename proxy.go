@@ -0,0 +1,173 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// streamSigningKey is resolved lazily (rather than at package init) so a
+// test binary that never signs a token never trips the Fatal below, while a
+// running addon still fails fast on its first real stream request instead
+// of silently signing with a key anyone reading this (public) source knows.
+var (
+	signingKeyOnce   sync.Once
+	streamSigningKey []byte
+)
+
+func getSigningKey() []byte {
+	signingKeyOnce.Do(func() {
+		key := getEnv("DVR_STREAM_SIGNING_KEY", "")
+		if key == "" {
+			log.Fatal("[DVR] DVR_STREAM_SIGNING_KEY must be set: stream proxy URLs are only safe because they're signed, and a hard-coded fallback key would let anyone who has read this source forge a token for any recording")
+		}
+		streamSigningKey = []byte(key)
+	})
+	return streamSigningKey
+}
+
+// signStreamToken produces a short-lived "<recordingID>.<expiry>.<hmac>" token,
+// with the hmac bound to both the recording ID and the issuing backend's
+// identity, so the proxied URL Stremio receives is not a bearer credential
+// for EasyProxy itself and can't be replayed against a different backend.
+func signStreamToken(recordingID string, backend Backend, ttl time.Duration) string {
+	exp := time.Now().Add(ttl).Unix()
+	mac := hmacFor(recordingID, backend, exp)
+	return fmt.Sprintf("%s.%d.%s", recordingID, exp, mac)
+}
+
+func hmacFor(recordingID string, backend Backend, exp int64) string {
+	h := hmac.New(sha256.New, getSigningKey())
+	fmt.Fprintf(h, "%s.%s.%d", recordingID, backendCacheKey(backend), exp)
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// parseStreamToken splits a token into its claimed recording ID, expiry, and
+// mac without verifying the signature. handleProxy needs the recording ID
+// (to resolve which backend the request's config says it belongs to)
+// *before* it can verify a token, since the mac is bound to that backend.
+func parseStreamToken(token string) (recordingID string, exp int64, mac string, ok bool) {
+	parts := strings.SplitN(token, ".", 3)
+	if len(parts) != 3 {
+		return "", 0, "", false
+	}
+	exp, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", 0, "", false
+	}
+	return parts[0], exp, parts[2], true
+}
+
+// verifyStreamToken checks a token's expiry and signature against the given
+// backend. A token signed for one backend will not verify against another,
+// even if it's replayed through a config that reuses the same backend name.
+func verifyStreamToken(token string, backend Backend) (string, bool) {
+	recordingID, exp, mac, ok := parseStreamToken(token)
+	if !ok || time.Now().Unix() > exp {
+		return "", false
+	}
+
+	expected := hmacFor(recordingID, backend, exp)
+	if subtle.ConstantTimeCompare([]byte(mac), []byte(expected)) != 1 {
+		return "", false
+	}
+	return recordingID, true
+}
+
+// proxyStreamURL builds the signed /proxy/<token> URL that handleStream should
+// hand to Stremio instead of the raw EasyProxy URL. When the request came in
+// through a /c/<cfg>/ route, the link keeps that prefix so handleProxy can
+// resolve the right backend later.
+func proxyStreamURL(r *http.Request, backend Backend, recordingID string) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	token := signStreamToken(recordingID, backend, 6*time.Hour)
+
+	if raw, ok := rawConfigFromRequest(r); ok {
+		return fmt.Sprintf("%s://%s/c/%s/proxy/%s", scheme, r.Host, raw, token)
+	}
+	return fmt.Sprintf("%s://%s/proxy/%s", scheme, r.Host, token)
+}
+
+// Handler: Proxy. Streams the recording from EasyProxy through the addon so the
+// player never sees the upstream URL or api password, forwarding Range requests
+// so seeking works.
+func handleProxy(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.URL.Path, "/proxy/")
+
+	// The recording ID is read from the token before verification purely to
+	// resolve which backend to check the signature against; it carries no
+	// authority on its own until verifyStreamToken confirms the mac below.
+	claimedID, _, _, ok := parseStreamToken(token)
+	if !ok {
+		http.Error(w, "invalid or expired stream token", http.StatusForbidden)
+		return
+	}
+
+	cfg := configFromRequest(r)
+	backendName, _ := splitBackendID(claimedID)
+	backend, ok := backendByName(cfg, backendName)
+	if !ok {
+		http.Error(w, "unknown backend", http.StatusBadRequest)
+		return
+	}
+
+	recordingID, ok := verifyStreamToken(token, backend)
+	if !ok {
+		http.Error(w, "invalid or expired stream token", http.StatusForbidden)
+		return
+	}
+
+	params := url.Values{}
+	if backend.Password != "" {
+		params.Set("api_password", backend.Password)
+	}
+	upstreamURL := fmt.Sprintf("%s/api/recordings/%s/stream?%s", backend.URL, recordingID, params.Encode())
+
+	req, err := http.NewRequestWithContext(r.Context(), "GET", upstreamURL, nil)
+	if err != nil {
+		http.Error(w, "failed to build upstream request", http.StatusInternalServerError)
+		return
+	}
+	if backend.Password != "" {
+		req.Header.Set("x-api-password", backend.Password)
+	}
+	for _, h := range []string{"Range", "If-Modified-Since", "Accept-Encoding"} {
+		if v := r.Header.Get(h); v != "" {
+			req.Header.Set(h, v)
+		}
+	}
+
+	client := &http.Client{Timeout: 0}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Printf("[DVR] Proxy: upstream request failed for %s: %v", recordingID, err)
+		http.Error(w, "upstream request failed", http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	for _, h := range []string{"Content-Range", "Content-Length", "Content-Type", "Accept-Ranges"} {
+		if v := resp.Header.Get(h); v != "" {
+			w.Header().Set(h, v)
+		}
+	}
+
+	w.WriteHeader(resp.StatusCode)
+	buf := make([]byte, 64*1024)
+	if _, err := io.CopyBuffer(w, resp.Body, buf); err != nil {
+		log.Printf("[DVR] Proxy: error streaming %s: %v", recordingID, err)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	os.Setenv("DVR_STREAM_SIGNING_KEY", "test-signing-key")
+	os.Exit(m.Run())
+}
+
+var testBackendA = Backend{Name: "default", URL: "https://backend-a.example.com", Password: "pw-a"}
+var testBackendB = Backend{Name: "default", URL: "https://backend-b.example.com", Password: "pw-b"}
+
+func TestSignAndVerifyStreamToken(t *testing.T) {
+	token := signStreamToken("default:rec123", testBackendA, time.Hour)
+
+	id, ok := verifyStreamToken(token, testBackendA)
+	if !ok {
+		t.Fatalf("verifyStreamToken(%q) failed, want ok", token)
+	}
+	if id != "default:rec123" {
+		t.Errorf("recordingID = %q, want %q", id, "default:rec123")
+	}
+}
+
+func TestVerifyStreamToken(t *testing.T) {
+	valid := signStreamToken("rec1", testBackendA, time.Hour)
+	expired := signStreamToken("rec1", testBackendA, -time.Hour)
+
+	tests := []struct {
+		name    string
+		token   string
+		backend Backend
+		want    bool
+	}{
+		{"valid token", valid, testBackendA, true},
+		{"expired token", expired, testBackendA, false},
+		{"tampered recording id", "rec2" + valid[len("rec1"):], testBackendA, false},
+		{"malformed: missing parts", "rec1.123", testBackendA, false},
+		{"malformed: empty", "", testBackendA, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := verifyStreamToken(tt.token, tt.backend)
+			if ok != tt.want {
+				t.Errorf("verifyStreamToken(%q) ok = %v, want %v", tt.token, ok, tt.want)
+			}
+		})
+	}
+}
+
+// TestVerifyStreamTokenRejectsCrossBackendReplay reproduces the SSRF replay:
+// a token legitimately signed for one backend must not verify against a
+// different backend, even though both share the same display Name (e.g.
+// both configs call their only backend "default").
+func TestVerifyStreamTokenRejectsCrossBackendReplay(t *testing.T) {
+	token := signStreamToken("default:rec123", testBackendA, time.Hour)
+
+	if _, ok := verifyStreamToken(token, testBackendB); ok {
+		t.Fatal("verifyStreamToken accepted a token signed for a different backend sharing the same Name")
+	}
+}
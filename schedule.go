@@ -0,0 +1,445 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ScheduleEntry represents a reserved/queued recording that hasn't started yet.
+type ScheduleEntry struct {
+	ID          string    `json:"id"`
+	ShowName    string    `json:"show_name"`
+	Channel     string    `json:"channel"`
+	StartAt     time.Time `json:"start_at"`
+	EndAt       time.Time `json:"end_at"`
+	Series      bool      `json:"series"`
+	Status      string    `json:"status"` // "pending", "armed", "converted", "cancelled"
+	RecordingID string    `json:"recording_id,omitempty"`
+}
+
+var scheduleBucket = []byte("schedules")
+
+// ScheduleStore persists schedule entries in a local BoltDB file so reservations
+// survive addon restarts even when EasyProxy itself has forgotten about them.
+type ScheduleStore struct {
+	db *bolt.DB
+}
+
+func openScheduleStore(path string) (*ScheduleStore, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(scheduleBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &ScheduleStore{db: db}, nil
+}
+
+func (s *ScheduleStore) Put(entry ScheduleEntry) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(scheduleBucket).Put([]byte(entry.ID), data)
+	})
+}
+
+func (s *ScheduleStore) Get(id string) (ScheduleEntry, bool) {
+	var entry ScheduleEntry
+	found := false
+	s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(scheduleBucket).Get([]byte(id))
+		if data == nil {
+			return nil
+		}
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return err
+		}
+		found = true
+		return nil
+	})
+	return entry, found
+}
+
+func (s *ScheduleStore) List() ([]ScheduleEntry, error) {
+	var entries []ScheduleEntry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(scheduleBucket).ForEach(func(k, v []byte) error {
+			var entry ScheduleEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	})
+	return entries, err
+}
+
+func (s *ScheduleStore) Delete(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(scheduleBucket).Delete([]byte(id))
+	})
+}
+
+var scheduleStore *ScheduleStore
+
+func scheduleEntryToMeta(entry ScheduleEntry) StremioMeta {
+	name := entry.ShowName
+	if name == "" {
+		name = "Unknown Program"
+	}
+	if entry.Series {
+		name += " (series)"
+	}
+
+	description := fmt.Sprintf("Reserved on %s", entry.Channel)
+	if entry.Status == "armed" {
+		description = fmt.Sprintf("Arming soon on %s", entry.Channel)
+	}
+
+	return StremioMeta{
+		ID:          "sched:" + entry.ID,
+		Type:        "tv",
+		Name:        name,
+		Description: description,
+		ReleaseInfo: entry.StartAt.Format(time.RFC3339),
+	}
+}
+
+// Handler: Schedule catalog. Lists queued/reserved recordings as metas whose
+// releaseInfo is the planned start time.
+func handleScheduleCatalog(w http.ResponseWriter, r *http.Request) {
+	entries, err := scheduleStore.List()
+	if err != nil {
+		log.Printf("[DVR] Error listing schedule: %v", err)
+		jsonResponse(w, map[string][]StremioMeta{"metas": {}})
+		return
+	}
+
+	var pending []ScheduleEntry
+	for _, entry := range entries {
+		if entry.Status == "pending" || entry.Status == "armed" {
+			pending = append(pending, entry)
+		}
+	}
+
+	metas := make([]StremioMeta, len(pending))
+	for i, entry := range pending {
+		metas[i] = scheduleEntryToMeta(entry)
+	}
+
+	jsonResponse(w, map[string][]StremioMeta{"metas": metas})
+}
+
+// scheduleCancelURL builds the absolute /schedule/cancel/<id> URL for the
+// current request's host/scheme, mirroring proxyStreamURL's approach.
+func scheduleCancelURL(r *http.Request, scheduleID string) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/schedule/cancel/%s", scheme, r.Host, scheduleID)
+}
+
+// createScheduleResponse is the subset of EasyProxy's schedule-create
+// response this addon needs: the ID it assigned the new reservation.
+type createScheduleResponse struct {
+	ID string `json:"id"`
+}
+
+// createScheduleOnBackend asks EasyProxy to create the reservation itself,
+// returning the ID EasyProxy assigned it. Later arm/cancel/record-now/
+// record-series calls address that ID directly, so EasyProxy always knows
+// what they refer to instead of being handed an ID this addon made up.
+func createScheduleOnBackend(ctx context.Context, entry ScheduleEntry) (string, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"show_name": entry.ShowName,
+		"channel":   entry.Channel,
+		"start_at":  entry.StartAt,
+		"end_at":    entry.EndAt,
+		"series":    entry.Series,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	if easyProxyPassword != "" {
+		params.Set("api_password", easyProxyPassword)
+	}
+	reqURL := fmt.Sprintf("%s/api/schedule/create?%s", easyProxyURL, params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if easyProxyPassword != "" {
+		req.Header.Set("x-api-password", easyProxyPassword)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("EasyProxy returned status %d creating schedule entry", resp.StatusCode)
+	}
+
+	var body createScheduleResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if body.ID == "" {
+		return "", fmt.Errorf("EasyProxy did not return a schedule id")
+	}
+	return body.ID, nil
+}
+
+// Handler: POST-from-stream /schedule/create. Stremio has no native "create"
+// UI, so this is meant to be driven by a companion EPG page or a small
+// userscript that POSTs the fields a user picked for a future recording.
+// The reservation is created on EasyProxy first so the local store's ID
+// matches the one EasyProxy itself will expect in later arm/cancel calls.
+func handleScheduleCreate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		ShowName string    `json:"show_name"`
+		Channel  string    `json:"channel"`
+		StartAt  time.Time `json:"start_at"`
+		EndAt    time.Time `json:"end_at"`
+		Series   bool      `json:"series"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if body.ShowName == "" || body.StartAt.IsZero() {
+		http.Error(w, "show_name and start_at are required", http.StatusBadRequest)
+		return
+	}
+
+	entry := ScheduleEntry{
+		ShowName: body.ShowName,
+		Channel:  body.Channel,
+		StartAt:  body.StartAt,
+		EndAt:    body.EndAt,
+		Series:   body.Series,
+		Status:   "pending",
+	}
+
+	remoteID, err := createScheduleOnBackend(r.Context(), entry)
+	if err != nil {
+		log.Printf("[DVR] Schedule: failed to create %q on EasyProxy: %v", entry.ShowName, err)
+		http.Error(w, "failed to create reservation on EasyProxy", http.StatusBadGateway)
+		return
+	}
+	entry.ID = remoteID
+
+	if err := scheduleStore.Put(entry); err != nil {
+		log.Printf("[DVR] Schedule: failed to save %q: %v", entry.ShowName, err)
+		http.Error(w, "failed to save reservation", http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]ScheduleEntry{"schedule": entry})
+}
+
+// Handler: POST /schedule/cancel/<id>. Cancels the reservation on the
+// EasyProxy side and removes it from the local store so it stops showing up
+// in the dvr-schedule catalog and runScheduler doesn't arm it again.
+func handleScheduleCancel(w http.ResponseWriter, r *http.Request) {
+	scheduleID := strings.TrimPrefix(r.URL.Path, "/schedule/cancel/")
+	if scheduleID == "" {
+		http.Error(w, "missing schedule id", http.StatusBadRequest)
+		return
+	}
+
+	params := url.Values{}
+	if easyProxyPassword != "" {
+		params.Set("api_password", easyProxyPassword)
+	}
+	reqURL := fmt.Sprintf("%s/api/schedule/%s/cancel?%s", easyProxyURL, scheduleID, params.Encode())
+
+	req, err := http.NewRequestWithContext(r.Context(), "POST", reqURL, nil)
+	if err == nil {
+		if easyProxyPassword != "" {
+			req.Header.Set("x-api-password", easyProxyPassword)
+		}
+		resp, doErr := httpClient.Do(req)
+		if doErr != nil {
+			log.Printf("[DVR] Schedule: cancel request to EasyProxy failed for %s: %v", scheduleID, doErr)
+		} else {
+			resp.Body.Close()
+		}
+	} else {
+		log.Printf("[DVR] Schedule: failed to build cancel request for %s: %v", scheduleID, err)
+	}
+
+	if err := scheduleStore.Delete(scheduleID); err != nil {
+		log.Printf("[DVR] Schedule: failed to delete cancelled entry %s: %v", scheduleID, err)
+		http.Error(w, "failed to delete reservation", http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]bool{"ok": true})
+}
+
+func handleScheduleMeta(w http.ResponseWriter, scheduleID string) {
+	entry, ok := scheduleStore.Get(scheduleID)
+	if !ok {
+		jsonResponse(w, map[string]interface{}{"meta": nil})
+		return
+	}
+	jsonResponse(w, map[string]StremioMeta{"meta": scheduleEntryToMeta(entry)})
+}
+
+func handleScheduleStream(w http.ResponseWriter, r *http.Request, scheduleID string) {
+	entry, ok := scheduleStore.Get(scheduleID)
+	if !ok {
+		jsonResponse(w, map[string][]StremioStream{"streams": {}})
+		return
+	}
+
+	params := url.Values{}
+	if easyProxyPassword != "" {
+		params.Set("api_password", easyProxyPassword)
+	}
+
+	var streams []StremioStream
+	streams = append(streams, StremioStream{
+		// Routed through our own /schedule/cancel so the local store entry is
+		// deleted too, not just the reservation on the EasyProxy side.
+		URL:   scheduleCancelURL(r, entry.ID),
+		Title: "Cancel reservation",
+	})
+	streams = append(streams, StremioStream{
+		URL:   fmt.Sprintf("%s/api/schedule/%s/record-now?%s", easyProxyURL, entry.ID, params.Encode()),
+		Title: "Record now",
+	})
+	if entry.Series {
+		streams = append(streams, StremioStream{
+			URL:   fmt.Sprintf("%s/api/schedule/%s/record-series?%s", easyProxyURL, entry.ID, params.Encode()),
+			Title: "Record series",
+		})
+	}
+
+	jsonResponse(w, map[string][]StremioStream{"streams": streams})
+}
+
+// runScheduler polls the schedule store every pollInterval. Entries whose start
+// time has arrived are POSTed to EasyProxy; entries that EasyProxy reports as
+// now "recording" are converted into plain recordings and dropped from the
+// store, mirroring livedl's auto-reserve-then-record behavior.
+func runScheduler(store *ScheduleStore, pollInterval time.Duration) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		entries, err := store.List()
+		if err != nil {
+			log.Printf("[DVR] Scheduler: error listing entries: %v", err)
+			continue
+		}
+
+		recordings, recErr := fetchRecordings(context.Background())
+
+		for _, entry := range entries {
+			switch entry.Status {
+			case "pending":
+				if !time.Now().Before(entry.StartAt) {
+					if err := armSchedule(entry); err != nil {
+						log.Printf("[DVR] Scheduler: failed to arm %s: %v", entry.ID, err)
+						continue
+					}
+					entry.Status = "armed"
+					store.Put(entry)
+				}
+			case "armed":
+				if recErr != nil {
+					continue
+				}
+				for _, rec := range recordings {
+					if rec.IsActive && rec.Status == "recording" && scheduleMatchesRecording(entry, rec) {
+						entry.Status = "converted"
+						entry.RecordingID = rec.ID
+						store.Put(entry)
+						log.Printf("[DVR] Scheduler: %s converted into recording %s", entry.ID, rec.ID)
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+func scheduleMatchesRecording(entry ScheduleEntry, rec Recording) bool {
+	return strings.Contains(strings.ToLower(rec.Name), strings.ToLower(entry.ShowName))
+}
+
+func armSchedule(entry ScheduleEntry) error {
+	params := url.Values{}
+	if easyProxyPassword != "" {
+		params.Set("api_password", easyProxyPassword)
+	}
+	reqURL := fmt.Sprintf("%s/api/schedule/%s/start?%s", easyProxyURL, entry.ID, params.Encode())
+
+	req, err := http.NewRequestWithContext(context.Background(), "POST", reqURL, nil)
+	if err != nil {
+		return err
+	}
+	if easyProxyPassword != "" {
+		req.Header.Set("x-api-password", easyProxyPassword)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("EasyProxy returned status %d arming %s", resp.StatusCode, entry.ID)
+	}
+	return nil
+}
+
+func getEnvInt(key string, fallback int) int {
+	if value := getEnv(key, ""); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
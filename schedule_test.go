@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCreateScheduleOnBackend(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/schedule/create" {
+			t.Errorf("path = %q, want /api/schedule/create", r.URL.Path)
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		if body["show_name"] != "Nightly News" {
+			t.Errorf("show_name = %v, want %q", body["show_name"], "Nightly News")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"ep-42"}`))
+	}))
+	defer srv.Close()
+
+	origURL := easyProxyURL
+	easyProxyURL = srv.URL
+	defer func() { easyProxyURL = origURL }()
+
+	entry := ScheduleEntry{ShowName: "Nightly News", Channel: "BBC One", StartAt: time.Now()}
+	id, err := createScheduleOnBackend(context.Background(), entry)
+	if err != nil {
+		t.Fatalf("createScheduleOnBackend returned error: %v", err)
+	}
+	if id != "ep-42" {
+		t.Errorf("id = %q, want %q", id, "ep-42")
+	}
+}
+
+func TestCreateScheduleOnBackendRejectsMissingID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	origURL := easyProxyURL
+	easyProxyURL = srv.URL
+	defer func() { easyProxyURL = origURL }()
+
+	if _, err := createScheduleOnBackend(context.Background(), ScheduleEntry{ShowName: "X"}); err == nil {
+		t.Fatal("createScheduleOnBackend returned nil error for a response with no id")
+	}
+}
+
+// TestHandleScheduleCreateEndToEnd reproduces the fix for the create flow:
+// the local store entry must be keyed by the ID EasyProxy assigned, not one
+// minted locally, so later arm/cancel calls address something EasyProxy
+// actually knows about.
+func TestHandleScheduleCreateEndToEnd(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"ep-99"}`))
+	}))
+	defer srv.Close()
+
+	origURL := easyProxyURL
+	easyProxyURL = srv.URL
+	defer func() { easyProxyURL = origURL }()
+
+	store, err := openScheduleStore(filepath.Join(t.TempDir(), "schedule.db"))
+	if err != nil {
+		t.Fatalf("openScheduleStore returned error: %v", err)
+	}
+	defer store.db.Close()
+	origStore := scheduleStore
+	scheduleStore = store
+	defer func() { scheduleStore = origStore }()
+
+	body := bytes.NewBufferString(`{"show_name":"Nightly News","start_at":"2026-01-01T20:00:00Z"}`)
+	req := httptest.NewRequest(http.MethodPost, "/schedule/create", body)
+	rec := httptest.NewRecorder()
+
+	handleScheduleCreate(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body=%s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	entry, ok := scheduleStore.Get("ep-99")
+	if !ok {
+		t.Fatal("expected entry to be stored under EasyProxy's assigned id \"ep-99\"")
+	}
+	if entry.ShowName != "Nightly News" {
+		t.Errorf("ShowName = %q, want %q", entry.ShowName, "Nightly News")
+	}
+}